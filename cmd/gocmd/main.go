@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cyverse/gocommands/cmd/subcmd"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   "gocmd",
+	Short: "gocommands is a command-line iRODS client",
+	Long:  `gocommands is a command-line iRODS client, compatible with icommands.`,
+}
+
+func Execute(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
+
+func main() {
+	logger := log.WithFields(log.Fields{
+		"package":  "main",
+		"function": "main",
+	})
+
+	subcmd.AddInitCommand(rootCmd)
+	subcmd.AddLsCommand(rootCmd)
+	subcmd.AddRmCommand(rootCmd)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := Execute(ctx)
+	if err != nil {
+		logger.Fatal(err)
+		os.Exit(1)
+	}
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
 
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	"github.com/cyverse/gocommands/commons"
+	"github.com/cyverse/gocommands/commons/filter"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -18,8 +25,13 @@ var rootCmd = &cobra.Command{
 	RunE:  processCommand,
 }
 
-func Execute() error {
-	return rootCmd.Execute()
+// filterFlags collects the --include/--exclude/--exclude-from flags
+// attached to rootCmd in main; processCommand resolves them once args
+// have been parsed.
+var filterFlags *filter.FlagRules
+
+func Execute(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
 }
 
 func processCommand(command *cobra.Command, args []string) error {
@@ -44,6 +56,9 @@ func processCommand(command *cobra.Command, args []string) error {
 		return err
 	}
 
+	ctx, cancel := commons.GetTimeoutContext(command)
+	defer cancel()
+
 	// Create a file system
 	account := commons.GetAccount()
 
@@ -54,25 +69,177 @@ func processCommand(command *cobra.Command, args []string) error {
 
 	defer filesystem.Release()
 
-	if len(args) == 1 {
-		// upload to current collection
-		err = putOne(filesystem, args[0], "./")
-		if err != nil {
-			logger.Error(err)
-			return err
+	noProgress := getBoolFlag(command, "no-progress")
+	ignoreErrors := getBoolFlag(command, "ignore-errors")
+	dryRun := getBoolFlag(command, "dry-run")
+
+	transfers := commons.DefaultTransfers()
+	if transfersFlag := command.Flags().Lookup("transfers"); transfersFlag != nil {
+		if n, err := strconv.Atoi(transfersFlag.Value.String()); err == nil && n > 0 {
+			transfers = n
+		}
+	}
+
+	chunkWorkers := 0
+	if chunkWorkersFlag := command.Flags().Lookup("chunk-workers"); chunkWorkersFlag != nil {
+		if n, err := strconv.Atoi(chunkWorkersFlag.Value.String()); err == nil {
+			chunkWorkers = n
+		}
+	}
+
+	retries := 0
+	if retriesFlag := command.Flags().Lookup("retries"); retriesFlag != nil {
+		if n, err := strconv.Atoi(retriesFlag.Value.String()); err == nil {
+			retries = n
+		}
+	}
+
+	lowLevelRetries := 0
+	if lowLevelRetriesFlag := command.Flags().Lookup("low-level-retries"); lowLevelRetriesFlag != nil {
+		if n, err := strconv.Atoi(lowLevelRetriesFlag.Value.String()); err == nil {
+			lowLevelRetries = n
+		}
+	}
+
+	sourcePaths := args
+	if len(args) >= 2 {
+		sourcePaths = args[:len(args)-1]
+	}
+
+	filterSet, err := filterFlags.Resolve()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	totalFiles, totalBytes, err := sumUploadSize(sourcePaths, filterSet)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	progress := commons.NewTransferProgress(totalFiles, totalBytes, noProgress)
+	defer progress.Finish()
+
+	pool := &commons.TransferPool{
+		Transfers:       transfers,
+		Retries:         retries,
+		LowLevelRetries: lowLevelRetries,
+		IgnoreErrors:    ignoreErrors,
+		DryRun:          dryRun,
+		Transfer: func(ctx context.Context, task commons.TransferTask) error {
+			return putDataObject(ctx, filesystem, progress, chunkWorkers, task)
+		},
+	}
+
+	tasks := make(chan commons.TransferTask, transfers*2)
+
+	// runCtx/runCancel is shared by the walker and the pool so that a
+	// fatal transfer failure (which calls runCancel via pool.Run) also
+	// stops the walk early instead of it enumerating the whole source
+	// tree after the pool has already given up.
+	runCtx, runCancel := context.WithCancel(ctx)
+	defer runCancel()
+
+	var walkErr error
+	var walkWg sync.WaitGroup
+	walkWg.Add(1)
+
+	go func() {
+		defer walkWg.Done()
+		defer close(tasks)
+
+		if len(args) == 1 {
+			walkErr = walkOne(runCtx, filesystem, filterSet, dryRun, args[0], args[0], "./", tasks)
+			return
 		}
-	} else if len(args) >= 2 {
-		targetPath := args[len(args)-1]
-		for _, sourcePath := range args[:len(args)-1] {
-			err = putOne(filesystem, sourcePath, targetPath)
+
+		if len(args) >= 2 {
+			targetPath := args[len(args)-1]
+			for _, sourcePath := range sourcePaths {
+				if runCtx.Err() != nil {
+					walkErr = runCtx.Err()
+					return
+				}
+
+				if err := walkOne(runCtx, filesystem, filterSet, dryRun, sourcePath, sourcePath, targetPath, tasks); err != nil {
+					walkErr = err
+					return
+				}
+			}
+		}
+	}()
+
+	poolErr := pool.Run(runCtx, runCancel, tasks)
+	walkWg.Wait()
+
+	if walkErr != nil {
+		logger.Error(walkErr)
+		return walkErr
+	}
+
+	if poolErr != nil {
+		logger.Error(poolErr)
+		return poolErr
+	}
+
+	return nil
+}
+
+func getBoolFlag(command *cobra.Command, name string) bool {
+	flag := command.Flags().Lookup(name)
+	if flag == nil {
+		return false
+	}
+
+	value, err := strconv.ParseBool(flag.Value.String())
+	if err != nil {
+		return false
+	}
+
+	return value
+}
+
+// sumUploadSize walks sourcePaths to sum the number of files and total
+// bytes they contain, skipping anything filterSet excludes, so the
+// aggregate progress bar can show a meaningful ETA before any upload starts.
+func sumUploadSize(sourcePaths []string, filterSet *filter.Set) (int, int64, error) {
+	totalFiles := 0
+	var totalBytes int64
+
+	for _, sourcePath := range sourcePaths {
+		localPath := commons.MakeLocalPath(sourcePath)
+
+		err := filepath.Walk(localPath, func(walkedPath string, info os.FileInfo, err error) error {
 			if err != nil {
-				logger.Error(err)
 				return err
 			}
+
+			rel, relErr := filepath.Rel(localPath, walkedPath)
+			if relErr != nil {
+				return relErr
+			}
+
+			if rel != "." && !filterSet.Match(rel, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !info.IsDir() {
+				totalFiles++
+				totalBytes += info.Size()
+			}
+
+			return nil
+		})
+		if err != nil {
+			return 0, 0, err
 		}
 	}
 
-	return nil
+	return totalFiles, totalBytes, nil
 }
 
 func main() {
@@ -84,58 +251,121 @@ func main() {
 	// attach common flags
 	commons.SetCommonFlags(rootCmd)
 
-	err := Execute()
+	rootCmd.Flags().Bool("no-progress", false, "Disable progress bars")
+	rootCmd.Flags().Int("transfers", commons.DefaultTransfers(), "Number of concurrent file transfers")
+	rootCmd.Flags().Int("chunk-workers", 0, "Number of parallel chunk upload workers per file (0 = automatic)")
+	rootCmd.Flags().Int("retries", 0, "Number of times to retry a file transfer that fails with a transient error")
+	rootCmd.Flags().Int("low-level-retries", 0, "Number of low-level iRODS request retries (reserved)")
+	rootCmd.Flags().Bool("ignore-errors", false, "Continue past failed transfers and report a summary at the end")
+	rootCmd.Flags().Bool("dry-run", false, "Walk and report what would be uploaded without transferring data")
+	rootCmd.Flags().Duration("timeout", 0, "Overall time limit for the command (e.g. 30s, 5m); 0 disables the limit")
+	filterFlags = filter.RegisterFlags(rootCmd.Flags())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := Execute(ctx)
 	if err != nil {
 		logger.Fatal(err)
 		os.Exit(1)
 	}
 }
 
-func putOne(filesystem *irodsclient_fs.FileSystem, sourcePath string, targetPath string) error {
+// walkOne walks sourcePath (a file or directory), creating target
+// collections as it descends and streaming each file it finds into
+// tasks for the TransferPool to upload concurrently. When dryRun is set,
+// it neither creates collections nor contacts the server at all.
+func walkOne(ctx context.Context, filesystem *irodsclient_fs.FileSystem, filterSet *filter.Set, dryRun bool, root string, sourcePath string, targetPath string, tasks chan<- commons.TransferTask) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	sourcePath = commons.MakeLocalPath(sourcePath)
+	root = commons.MakeLocalPath(root)
+
 	cwd := commons.GetCWD()
-	targetPath = commons.MakeIRODSPath(cwd, targetPath)
+	home := commons.GetHomeDir()
+	zone := commons.GetZone()
+
+	absTargetPath, _, err := commons.ResolveIRODSPath(cwd, home, zone, targetPath)
+	if err != nil {
+		return xerrors.Errorf("failed to resolve path %s: %w", targetPath, err)
+	}
+	targetPath = absTargetPath
 
 	st, err := os.Stat(sourcePath)
 	if err != nil {
 		return err
 	}
 
+	if rel, relErr := filepath.Rel(root, sourcePath); relErr == nil && rel != "." {
+		if !filterSet.Match(rel, st.IsDir()) {
+			return nil
+		}
+	}
+
 	if !st.IsDir() {
-		return putDataObject(filesystem, sourcePath, targetPath)
-	} else {
-		// dir
-		entries, err := os.ReadDir(sourcePath)
-		if err != nil {
-			return err
+		select {
+		case tasks <- commons.TransferTask{SourcePath: sourcePath, TargetPath: targetPath, Size: st.Size()}:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+		return nil
+	}
 
-		// make target dir
-		targetDir := filepath.Join(targetPath, filepath.Base(sourcePath))
+	// dir
+	entries, err := os.ReadDir(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	// make target dir
+	targetDir := filepath.Join(targetPath, filepath.Base(sourcePath))
+	if !dryRun {
 		err = filesystem.MakeDir(targetDir, true)
 		if err != nil {
 			return err
 		}
+	}
 
-		for _, entryInDir := range entries {
-			err = putOne(filesystem, filepath.Join(sourcePath, entryInDir.Name()), targetDir)
-			if err != nil {
-				return err
-			}
+	for _, entryInDir := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err = walkOne(ctx, filesystem, filterSet, dryRun, root, filepath.Join(sourcePath, entryInDir.Name()), targetDir, tasks)
+		if err != nil {
+			return err
 		}
 	}
+
 	return nil
 }
 
-func putDataObject(filesystem *irodsclient_fs.FileSystem, sourcePath string, targetPath string) error {
+func putDataObject(ctx context.Context, filesystem *irodsclient_fs.FileSystem, progress *commons.TransferProgress, chunkWorkers int, task commons.TransferTask) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "main",
 		"function": "putDataObject",
 	})
 
-	logger.Debugf("uploading a file %s to an iRODS collection %s\n", sourcePath, targetPath)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	logger.Debugf("uploading a file %s to an iRODS collection %s\n", task.SourcePath, task.TargetPath)
+
+	// processed is cumulative bytes transferred so far, as reported by
+	// the library; only feed the bar the delta since the last callback.
+	var processedSoFar int64
+	callback := func(taskName string, processed int64, total int64) {
+		delta := processed - processedSoFar
+		if delta > 0 {
+			progress.AddBytes(delta)
+			processedSoFar = processed
+		}
+	}
 
-	err := filesystem.UploadFileParallel(sourcePath, targetPath, "", 0, false)
+	_, err := filesystem.UploadFileParallel(task.SourcePath, task.TargetPath, "", chunkWorkers, false, false, callback)
 	if err != nil {
 		return err
 	}
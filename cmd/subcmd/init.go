@@ -1,8 +1,10 @@
 package subcmd
 
 import (
+	"context"
 	"fmt"
 
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/gocommands/commons"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
@@ -20,6 +22,7 @@ var initCmd = &cobra.Command{
 func AddInitCommand(rootCmd *cobra.Command) {
 	// attach common flags
 	commons.SetCommonFlags(initCmd)
+	initCmd.Flags().Duration("timeout", 0, "Overall time limit for the command (e.g. 30s, 5m); 0 disables the limit")
 
 	rootCmd.AddCommand(initCmd)
 }
@@ -40,13 +43,19 @@ func processInitCommand(command *cobra.Command, args []string) error {
 		return xerrors.Errorf("failed to input missing fields: %w", err)
 	}
 
+	ctx, cancel := commons.GetTimeoutContext(command)
+	defer cancel()
+
 	account, err := commons.GetEnvironmentManager().ToIRODSAccount()
 	if err != nil {
 		return xerrors.Errorf("failed to get iRODS account info from iCommands Environment: %w", err)
 	}
 
-	err = commons.TestConnect(account)
-	if err != nil {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := testConnectWithContext(ctx, account); err != nil {
 		return xerrors.Errorf("failed to connect to iRODS server: %w", err)
 	}
 
@@ -65,3 +74,22 @@ func processInitCommand(command *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+// testConnectWithContext runs commons.TestConnect on a goroutine and
+// returns as soon as either it completes or ctx is done, so a slow or
+// unreachable iRODS server can't make --timeout a no-op: TestConnect
+// itself has no context parameter to cancel the in-flight connection
+// attempt, but the caller no longer blocks past the deadline waiting on it.
+func testConnectWithContext(ctx context.Context, account *irodsclient_types.IRODSAccount) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- commons.TestConnect(account)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,7 +1,8 @@
 package subcmd
 
 import (
-	"fmt"
+	"context"
+	"os"
 	"path"
 	"sort"
 	"strconv"
@@ -10,10 +11,15 @@ import (
 	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
 	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/gocommands/commons"
+	"github.com/cyverse/gocommands/commons/filter"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 )
 
+// lsFilterFlags collects the --include/--exclude/--exclude-from flags
+// attached to lsCmd; processLsCommand resolves them once args are parsed.
+var lsFilterFlags *filter.FlagRules
+
 var lsCmd = &cobra.Command{
 	Use:   "ls [collection1] [collection2] ...",
 	Short: "List entries in iRODS collections",
@@ -27,6 +33,9 @@ func AddLsCommand(rootCmd *cobra.Command) {
 
 	lsCmd.Flags().BoolP("long", "l", false, "List data objects in a long format")
 	lsCmd.Flags().BoolP("verylong", "L", false, "List data objects in a very long format")
+	lsCmd.Flags().String("format", "", "Output format, one of text, long, verylong, json, jsonl")
+	lsCmd.Flags().Duration("timeout", 0, "Overall time limit for the command (e.g. 30s, 5m); 0 disables the limit")
+	lsFilterFlags = filter.RegisterFlags(lsCmd.Flags())
 
 	rootCmd.AddCommand(lsCmd)
 }
@@ -65,6 +74,34 @@ func processLsCommand(command *cobra.Command, args []string) error {
 		}
 	}
 
+	format := ""
+	formatFlag := command.Flags().Lookup("format")
+	if formatFlag != nil {
+		format = formatFlag.Value.String()
+	}
+
+	if format == "" {
+		switch {
+		case veryLongFormat:
+			format = "verylong"
+		case longFormat:
+			format = "long"
+		}
+	}
+
+	formatter, err := commons.NewFormatter(format)
+	if err != nil {
+		return xerrors.Errorf("failed to create output formatter: %w", err)
+	}
+
+	filterSet, err := lsFilterFlags.Resolve()
+	if err != nil {
+		return xerrors.Errorf("failed to resolve include/exclude filters: %w", err)
+	}
+
+	ctx, cancel := commons.GetTimeoutContext(command)
+	defer cancel()
+
 	// Create a file system
 	account := commons.GetAccount()
 	filesystem, err := commons.GetIRODSFSClient(account)
@@ -81,113 +118,160 @@ func processLsCommand(command *cobra.Command, args []string) error {
 	}
 
 	for _, sourcePath := range sourcePaths {
-		err = listOne(filesystem, sourcePath, longFormat, veryLongFormat)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		entries, err := listOne(ctx, filesystem, sourcePath)
 		if err != nil {
 			return xerrors.Errorf("failed to perform ls %s: %w", sourcePath, err)
 		}
+
+		entries = filterEntries(entries, filterSet)
+
+		if err := formatter.Write(os.Stdout, entries); err != nil {
+			return xerrors.Errorf("failed to write ls output for %s: %w", sourcePath, err)
+		}
 	}
 
-	return nil
+	return formatter.Close(os.Stdout)
 }
 
-func listOne(fs *irodsclient_fs.FileSystem, sourcePath string, longFormat bool, veryLongFormat bool) error {
+func listOne(ctx context.Context, fs *irodsclient_fs.FileSystem, sourcePath string) ([]commons.ListEntry, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
 	cwd := commons.GetCWD()
 	home := commons.GetHomeDir()
 	zone := commons.GetZone()
-	sourcePath = commons.MakeIRODSPath(cwd, home, zone, sourcePath)
+
+	absPath, relPath, err := commons.ResolveIRODSPath(cwd, home, zone, sourcePath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to resolve path %s: %w", sourcePath, err)
+	}
 
 	connection, err := fs.GetMetadataConnection()
 	if err != nil {
-		return xerrors.Errorf("failed to get connection: %w", err)
+		return nil, xerrors.Errorf("failed to get connection: %w", err)
 	}
 	defer fs.ReturnMetadataConnection(connection)
 
-	collection, err := irodsclient_irodsfs.GetCollection(connection, sourcePath)
+	collection, err := irodsclient_irodsfs.GetCollection(connection, absPath)
 	if err != nil {
 		if !irodsclient_types.IsFileNotFoundError(err) {
-			return xerrors.Errorf("failed to get collection %s: %w", sourcePath, err)
+			return nil, xerrors.Errorf("failed to get collection %s: %w", relPath, err)
 		}
 	}
 
 	if err == nil {
-		colls, err := irodsclient_irodsfs.ListSubCollections(connection, sourcePath)
+		colls, err := irodsclient_irodsfs.ListSubCollections(connection, absPath)
 		if err != nil {
-			return xerrors.Errorf("failed to list sub-collections in %s: %w", sourcePath, err)
+			return nil, xerrors.Errorf("failed to list sub-collections in %s: %w", relPath, err)
 		}
 
 		objs, err := irodsclient_irodsfs.ListDataObjects(connection, collection)
 		if err != nil {
-			return xerrors.Errorf("failed to list data-objects in %s: %w", sourcePath, err)
+			return nil, xerrors.Errorf("failed to list data-objects in %s: %w", relPath, err)
 		}
 
-		printDataObjects(objs, veryLongFormat, longFormat)
-		printCollections(colls)
-		return nil
+		entries := collectionsToEntries(colls)
+		entries = append(entries, dataObjectsToEntries(objs)...)
+		return entries, nil
 	}
 
 	// data object
-	parentSourcePath := path.Dir(sourcePath)
+	parentAbsPath := path.Dir(absPath)
+	parentRelPath := path.Dir(relPath)
 
-	parentCollection, err := irodsclient_irodsfs.GetCollection(connection, parentSourcePath)
+	parentCollection, err := irodsclient_irodsfs.GetCollection(connection, parentAbsPath)
 	if err != nil {
-		return xerrors.Errorf("failed to get collection %s: %w", parentSourcePath, err)
+		return nil, xerrors.Errorf("failed to get collection %s: %w", parentRelPath, err)
 	}
 
-	entry, err := irodsclient_irodsfs.GetDataObject(connection, parentCollection, path.Base(sourcePath))
+	entry, err := irodsclient_irodsfs.GetDataObject(connection, parentCollection, path.Base(absPath))
 	if err != nil {
-		return xerrors.Errorf("failed to get data-object %s: %w", sourcePath, err)
+		return nil, xerrors.Errorf("failed to get data-object %s: %w", relPath, err)
 	}
 
-	printDataObject(entry, veryLongFormat, longFormat)
-	return nil
+	return []commons.ListEntry{dataObjectToEntry(entry)}, nil
 }
 
-func printDataObjects(entries []*irodsclient_types.IRODSDataObject, veryLongFormat bool, longFormat bool) {
+// filterEntries drops entries that filterSet excludes, matching each
+// entry's own name since ls only ever lists direct children.
+func filterEntries(entries []commons.ListEntry, filterSet *filter.Set) []commons.ListEntry {
+	if filterSet.Empty() {
+		return entries
+	}
+
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if filterSet.Match(entry.Name, entry.Dir) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	return filtered
+}
+
+func dataObjectsToEntries(objs []*irodsclient_types.IRODSDataObject) []commons.ListEntry {
 	// sort by name
-	sort.SliceStable(entries, func(i int, j int) bool {
-		return entries[i].Name < entries[j].Name
+	sort.SliceStable(objs, func(i int, j int) bool {
+		return objs[i].Name < objs[j].Name
 	})
 
-	for _, entry := range entries {
-		printDataObject(entry, veryLongFormat, longFormat)
+	entries := make([]commons.ListEntry, len(objs))
+	for i, obj := range objs {
+		entries[i] = dataObjectToEntry(obj)
 	}
+
+	return entries
 }
 
-func printDataObject(entry *irodsclient_types.IRODSDataObject, veryLongFormat bool, longFormat bool) {
-	if veryLongFormat {
-		for _, replica := range entry.Replicas {
-			modTime := commons.MakeDateTimeString(replica.ModifyTime)
-			fmt.Printf("  %s\t%d\t%s\t%d\t%s\t%s\t%s\n", replica.Owner, replica.Number, replica.ResourceHierarchy, entry.Size, modTime, getStatusMark(replica.Status), entry.Name)
-			fmt.Printf("    %s\t%s\n", replica.CheckSum, replica.Path)
-		}
-	} else if longFormat {
-		for _, replica := range entry.Replicas {
-			modTime := commons.MakeDateTimeString(replica.ModifyTime)
-			fmt.Printf("  %s\t%d\t%s\t%d\t%s\t%s\t%s\n", replica.Owner, replica.Number, replica.ResourceHierarchy, entry.Size, modTime, getStatusMark(replica.Status), entry.Name)
+func dataObjectToEntry(obj *irodsclient_types.IRODSDataObject) commons.ListEntry {
+	entry := commons.ListEntry{
+		Name: obj.Name,
+		Path: obj.Path,
+		Size: obj.Size,
+	}
+
+	for _, replica := range obj.Replicas {
+		if replica.Owner != "" {
+			entry.Owner = replica.Owner
 		}
-	} else {
-		fmt.Printf("  %s\n", entry.Name)
+
+		entry.Replicas = append(entry.Replicas, commons.ListReplica{
+			Number:            replica.Number,
+			Owner:             replica.Owner,
+			ResourceHierarchy: replica.ResourceHierarchy,
+			CheckSum:          replica.CheckSum,
+			Path:              replica.Path,
+			Status:            replica.Status,
+			ModifyTime:        commons.MakeDateTimeString(replica.ModifyTime),
+		})
+	}
+
+	if len(entry.Replicas) > 0 {
+		entry.ModTime = entry.Replicas[0].ModifyTime
 	}
+
+	return entry
 }
 
-func printCollections(entries []*irodsclient_types.IRODSCollection) {
+func collectionsToEntries(colls []*irodsclient_types.IRODSCollection) []commons.ListEntry {
 	// sort by name
-	sort.SliceStable(entries, func(i int, j int) bool {
-		return entries[i].Name < entries[j].Name
+	sort.SliceStable(colls, func(i int, j int) bool {
+		return colls[i].Name < colls[j].Name
 	})
 
-	for _, entry := range entries {
-		fmt.Printf("  C- %s\n", entry.Path)
+	entries := make([]commons.ListEntry, len(colls))
+	for i, coll := range colls {
+		entries[i] = commons.ListEntry{
+			Name: coll.Name,
+			Path: coll.Path,
+			Dir:  true,
+		}
 	}
-}
 
-func getStatusMark(status string) string {
-	switch status {
-	case "0":
-		return "X" // stale
-	case "1":
-		return "&" // good
-	default:
-		return "?"
-	}
+	return entries
 }
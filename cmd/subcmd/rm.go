@@ -1,15 +1,22 @@
 package subcmd
 
 import (
+	"context"
+	"path/filepath"
 	"strconv"
 
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	"github.com/cyverse/gocommands/commons"
+	"github.com/cyverse/gocommands/commons/filter"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 )
 
+// rmFilterFlags collects the --include/--exclude/--exclude-from flags
+// attached to rmCmd; processRmCommand resolves them once args are parsed.
+var rmFilterFlags *filter.FlagRules
+
 var rmCmd = &cobra.Command{
 	Use:   "rm [data-object1] [data-object2] [collection1] ...",
 	Short: "Remove iRODS data-objects or collections",
@@ -22,6 +29,8 @@ func AddRmCommand(rootCmd *cobra.Command) {
 	commons.SetCommonFlags(rmCmd)
 	rmCmd.Flags().BoolP("recurse", "r", false, "Remove non-empty collections")
 	rmCmd.Flags().BoolP("force", "f", false, "Remove forcefully")
+	rmCmd.Flags().Duration("timeout", 0, "Overall time limit for the command (e.g. 30s, 5m); 0 disables the limit")
+	rmFilterFlags = filter.RegisterFlags(rmCmd.Flags())
 
 	rootCmd.AddCommand(rmCmd)
 }
@@ -60,6 +69,14 @@ func processRmCommand(command *cobra.Command, args []string) error {
 		}
 	}
 
+	filterSet, err := rmFilterFlags.Resolve()
+	if err != nil {
+		return xerrors.Errorf("failed to resolve include/exclude filters: %w", err)
+	}
+
+	ctx, cancel := commons.GetTimeoutContext(command)
+	defer cancel()
+
 	// Create a file system
 	account := commons.GetAccount()
 	filesystem, err := commons.GetIRODSFSClient(account)
@@ -74,7 +91,11 @@ func processRmCommand(command *cobra.Command, args []string) error {
 	}
 
 	for _, sourcePath := range args {
-		err = removeOne(filesystem, sourcePath, force, recurse)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err = removeOne(ctx, filesystem, filterSet, sourcePath, force, recurse)
 		if err != nil {
 			return xerrors.Errorf("failed to perform rm %s: %w", sourcePath, err)
 		}
@@ -82,28 +103,36 @@ func processRmCommand(command *cobra.Command, args []string) error {
 	return nil
 }
 
-func removeOne(filesystem *irodsclient_fs.FileSystem, targetPath string, force bool, recurse bool) error {
+func removeOne(ctx context.Context, filesystem *irodsclient_fs.FileSystem, filterSet *filter.Set, targetPath string, force bool, recurse bool) error {
 	logger := log.WithFields(log.Fields{
 		"package":  "main",
 		"function": "removeOne",
 	})
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	cwd := commons.GetCWD()
 	home := commons.GetHomeDir()
 	zone := commons.GetZone()
-	targetPath = commons.MakeIRODSPath(cwd, home, zone, targetPath)
 
-	targetEntry, err := commons.StatIRODSPath(filesystem, targetPath)
+	absPath, relPath, err := commons.ResolveIRODSPath(cwd, home, zone, targetPath)
 	if err != nil {
-		return xerrors.Errorf("failed to stat %s: %w", targetPath, err)
+		return xerrors.Errorf("failed to resolve path %s: %w", targetPath, err)
+	}
+
+	targetEntry, err := commons.StatIRODSPath(filesystem, absPath)
+	if err != nil {
+		return xerrors.Errorf("failed to stat %s: %w", relPath, err)
 	}
 
 	if targetEntry.Type == irodsclient_fs.FileEntry {
 		// file
-		logger.Debugf("removing a data object %s", targetPath)
-		err = filesystem.RemoveFile(targetPath, force)
+		logger.Debugf("removing a data object %s", relPath)
+		err = filesystem.RemoveFile(absPath, force)
 		if err != nil {
-			return xerrors.Errorf("failed to remove %s: %w", targetPath, err)
+			return xerrors.Errorf("failed to remove %s: %w", relPath, err)
 		}
 	} else {
 		// dir
@@ -111,11 +140,71 @@ func removeOne(filesystem *irodsclient_fs.FileSystem, targetPath string, force b
 			return xerrors.Errorf("cannot remove a collection, recurse is not set")
 		}
 
-		logger.Debugf("removing a collection %s", targetPath)
-		err = filesystem.RemoveDir(targetPath, recurse, force)
+		if filterSet.Empty() {
+			logger.Debugf("removing a collection %s", relPath)
+			err = filesystem.RemoveDir(absPath, recurse, force)
+			if err != nil {
+				return xerrors.Errorf("failed to remove dir %s: %w", relPath, err)
+			}
+			return nil
+		}
+
+		logger.Debugf("removing a collection %s, applying include/exclude filters", relPath)
+		err = removeDirFiltered(ctx, filesystem, filterSet, absPath, absPath, force)
+		if err != nil {
+			return xerrors.Errorf("failed to remove dir %s: %w", relPath, err)
+		}
+	}
+	return nil
+}
+
+// removeDirFiltered descends targetPath (relative to root), removing
+// only the entries filterSet includes, then removes targetPath itself
+// if nothing excluded remains underneath it.
+func removeDirFiltered(ctx context.Context, filesystem *irodsclient_fs.FileSystem, filterSet *filter.Set, root string, targetPath string, force bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	entries, err := filesystem.List(targetPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list %s: %w", targetPath, err)
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(root, entry.Path)
 		if err != nil {
+			return err
+		}
+
+		isDir := entry.Type == irodsclient_fs.DirectoryEntry
+		if !filterSet.Match(rel, isDir) {
+			continue
+		}
+
+		if isDir {
+			if err := removeDirFiltered(ctx, filesystem, filterSet, root, entry.Path, force); err != nil {
+				return err
+			}
+		} else if err := filesystem.RemoveFile(entry.Path, force); err != nil {
+			return xerrors.Errorf("failed to remove %s: %w", entry.Path, err)
+		}
+	}
+
+	remaining, err := filesystem.List(targetPath)
+	if err != nil {
+		return xerrors.Errorf("failed to list %s: %w", targetPath, err)
+	}
+
+	if len(remaining) == 0 {
+		if err := filesystem.RemoveDir(targetPath, false, force); err != nil {
 			return xerrors.Errorf("failed to remove dir %s: %w", targetPath, err)
 		}
 	}
+
 	return nil
 }
@@ -0,0 +1,24 @@
+package commons
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// GetTimeoutContext derives a cancellable context from the command's context,
+// applying the --timeout flag as an overall deadline when set.
+func GetTimeoutContext(command *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := command.Context()
+
+	timeoutFlag := command.Flags().Lookup("timeout")
+	if timeoutFlag != nil {
+		timeout, err := time.ParseDuration(timeoutFlag.Value.String())
+		if err == nil && timeout > 0 {
+			return context.WithTimeout(ctx, timeout)
+		}
+	}
+
+	return context.WithCancel(ctx)
+}
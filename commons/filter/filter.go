@@ -0,0 +1,168 @@
+// Package filter implements gitignore/git-lfs style include/exclude
+// path filtering for recursive put/rm/ls, so users can write
+// `goput -r ./data --exclude '*.tmp' --exclude 'cache/**' --include 'important.tmp'`
+// instead of scripting around the lack of one.
+package filter
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rule is a single include/exclude pattern, as parsed from an --include,
+// --exclude, or --exclude-from line.
+type Rule struct {
+	Pattern string
+	Exclude bool // true for a --exclude rule, false for --include
+	Negate  bool // leading '!' on a --exclude rule means "don't exclude"; meaningless on --include
+	DirOnly bool // trailing '/' restricts the rule to directories
+}
+
+// ParseRule parses a single pattern, honoring a leading '!' for negation
+// and a trailing '/' to mean "directories only". Negate only has an
+// effect on --exclude/--exclude-from rules: --include is already
+// inclusionary, so a leading '!' there is stripped but otherwise ignored
+// rather than flipping it into an exclude rule.
+func ParseRule(pattern string, exclude bool) Rule {
+	r := Rule{Pattern: pattern, Exclude: exclude}
+
+	if strings.HasPrefix(r.Pattern, "!") {
+		r.Negate = true
+		r.Pattern = r.Pattern[1:]
+	}
+
+	if strings.HasSuffix(r.Pattern, "/") {
+		r.DirOnly = true
+		r.Pattern = strings.TrimSuffix(r.Pattern, "/")
+	}
+
+	return r
+}
+
+// LoadExcludeFrom reads newline-separated patterns from path (blank
+// lines and lines starting with '#' are ignored) and returns them as
+// exclude rules in file order.
+func LoadExcludeFrom(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []Rule
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rules = append(rules, ParseRule(line, true))
+	}
+
+	return rules, scanner.Err()
+}
+
+// Set is an ordered list of include/exclude rules. Rules are evaluated
+// gitignore style: rules are tested in the order given, and the last
+// matching rule wins; a path is included unless that last matching rule
+// excludes it (a path no rule matches is included).
+type Set struct {
+	rules []Rule
+}
+
+// NewSet builds a Set from an ordered list of rules, e.g. produced by
+// FlagRules.Resolve.
+func NewSet(rules []Rule) *Set {
+	return &Set{rules: rules}
+}
+
+// Empty reports whether the Set has no rules, i.e. it matches everything.
+func (s *Set) Empty() bool {
+	return s == nil || len(s.rules) == 0
+}
+
+// Match reports whether relPath (relative to the walk root) should be
+// included, applying rules in order and letting the last matching rule
+// decide. An empty Set always matches.
+func (s *Set) Match(relPath string, isDir bool) bool {
+	if s == nil || len(s.rules) == 0 {
+		return true
+	}
+
+	relPath = filepath.ToSlash(relPath)
+
+	included := true
+	for _, r := range s.rules {
+		if r.DirOnly && !isDir {
+			continue
+		}
+
+		if !matchGlob(r.Pattern, relPath) {
+			continue
+		}
+
+		excluded := r.Exclude
+		if r.Negate && r.Exclude {
+			excluded = !excluded
+		}
+
+		included = !excluded
+	}
+
+	return included
+}
+
+// matchGlob reports whether pattern matches path, supporting gitignore-
+// style "**" (match any number of path segments) in addition to the
+// standard filepath.Match wildcards ("*", "?", "[abc]").
+func matchGlob(pattern, path string) bool {
+	if strings.Contains(pattern, "**") {
+		return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+	}
+
+	if ok, _ := filepath.Match(pattern, path); ok {
+		return true
+	}
+
+	// a pattern without a slash also matches against the basename, as
+	// in gitignore.
+	if !strings.Contains(pattern, "/") {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(pattern []string, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	if pattern[0] == "**" {
+		if matchSegments(pattern[1:], path) {
+			return true
+		}
+
+		if len(path) == 0 {
+			return false
+		}
+
+		return matchSegments(pattern, path[1:])
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], path[1:])
+}
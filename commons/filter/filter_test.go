@@ -0,0 +1,100 @@
+package filter
+
+import "testing"
+
+func TestSetMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{
+			name: "no rules matches everything",
+			want: true,
+		},
+		{
+			name:  "exclude rule excludes a match",
+			rules: []Rule{ParseRule("*.tmp", true)},
+			path:  "a.tmp",
+			want:  false,
+		},
+		{
+			name:  "exclude rule leaves non-matches included",
+			rules: []Rule{ParseRule("*.tmp", true)},
+			path:  "a.go",
+			want:  true,
+		},
+		{
+			name:  "negated exclude re-includes",
+			rules: []Rule{ParseRule("*.tmp", true), ParseRule("!important.tmp", true)},
+			path:  "important.tmp",
+			want:  true,
+		},
+		{
+			name:  "later rule wins over earlier one",
+			rules: []Rule{ParseRule("*.tmp", true), ParseRule("cache/**", true), ParseRule("important.tmp", false)},
+			path:  "important.tmp",
+			want:  true,
+		},
+		{
+			name:  "negated include has no special effect, still re-includes by matching literally",
+			rules: []Rule{ParseRule("*.tmp", true), ParseRule("!important.tmp", false)},
+			path:  "important.tmp",
+			want:  true,
+		},
+		{
+			name:  "dir-only rule ignores files",
+			rules: []Rule{ParseRule("cache/", true)},
+			path:  "cache",
+			isDir: false,
+			want:  true,
+		},
+		{
+			name:  "dir-only rule matches directories",
+			rules: []Rule{ParseRule("cache/", true)},
+			path:  "cache",
+			isDir: true,
+			want:  false,
+		},
+		{
+			name:  "double-star matches across segments",
+			rules: []Rule{ParseRule("cache/**", true)},
+			path:  "cache/sub/dir/file.go",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := NewSet(tt.rules)
+			if got := set.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetEmpty(t *testing.T) {
+	if !NewSet(nil).Empty() {
+		t.Error("NewSet(nil) should be Empty")
+	}
+
+	if NewSet([]Rule{ParseRule("*.tmp", true)}).Empty() {
+		t.Error("NewSet with a rule should not be Empty")
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	r := ParseRule("!cache/", true)
+	if !r.Negate {
+		t.Error("expected Negate to be true")
+	}
+	if !r.DirOnly {
+		t.Error("expected DirOnly to be true")
+	}
+	if r.Pattern != "cache" {
+		t.Errorf("expected Pattern %q, got %q", "cache", r.Pattern)
+	}
+}
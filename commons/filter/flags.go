@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// ruleValue implements pflag.Value. Binding --include and --exclude to
+// two ruleValues over the same underlying slice keeps the rules in the
+// order they were actually given on the command line: pflag parses args
+// left to right and calls Set on whichever flag it encounters, so a
+// plain pair of StringArray flags (one list per name) would otherwise
+// lose that interleaving, breaking last-matching-rule-wins semantics.
+type ruleValue struct {
+	rules   *[]Rule
+	exclude bool
+}
+
+func (v *ruleValue) String() string {
+	return ""
+}
+
+func (v *ruleValue) Set(pattern string) error {
+	*v.rules = append(*v.rules, ParseRule(pattern, v.exclude))
+	return nil
+}
+
+func (v *ruleValue) Type() string {
+	return "stringArray"
+}
+
+// FlagRules collects --include/--exclude/--exclude-from flag values
+// until Resolve is called after the command's flags have been parsed.
+type FlagRules struct {
+	rules       []Rule
+	excludeFrom []string
+}
+
+// RegisterFlags attaches --include, --exclude and --exclude-from to
+// flags, returning a FlagRules to Resolve once cobra has parsed args.
+func RegisterFlags(flags *pflag.FlagSet) *FlagRules {
+	fr := &FlagRules{}
+
+	flags.Var(&ruleValue{rules: &fr.rules, exclude: false}, "include", "Include paths matching pattern (repeatable, gitignore-style globs, last match wins; leading '!' has no special effect here)")
+	flags.Var(&ruleValue{rules: &fr.rules, exclude: true}, "exclude", "Exclude paths matching pattern (repeatable, gitignore-style globs, last match wins; leading '!' re-includes)")
+	flags.StringArrayVar(&fr.excludeFrom, "exclude-from", nil, "Read exclude patterns from file (repeatable)")
+
+	return fr
+}
+
+// Resolve builds the final ordered Set, appending patterns from any
+// --exclude-from files after the --include/--exclude rules.
+func (fr *FlagRules) Resolve() (*Set, error) {
+	rules := append([]Rule{}, fr.rules...)
+
+	for _, path := range fr.excludeFrom {
+		fileRules, err := LoadExcludeFrom(path)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, fileRules...)
+	}
+
+	return NewSet(rules), nil
+}
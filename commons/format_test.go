@@ -0,0 +1,89 @@
+package commons
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONFormatterKeepsRawStatus(t *testing.T) {
+	entries := []ListEntry{
+		{
+			Name: "a.txt",
+			Path: "/zone/home/user/a.txt",
+			Replicas: []ListReplica{
+				{Number: 0, Status: "1"},
+			},
+		},
+	}
+
+	f, err := NewFormatter("json")
+	if err != nil {
+		t.Fatalf("NewFormatter: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf, entries); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := f.Close(&buf); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var got []ListEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal: %v\noutput: %s", err, buf.String())
+	}
+
+	if len(got) != 1 || len(got[0].Replicas) != 1 {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+
+	if got[0].Replicas[0].Status != "1" {
+		t.Errorf("expected raw status %q to survive json round-trip, got %q", "1", got[0].Replicas[0].Status)
+	}
+}
+
+func TestTextFormatterRendersStatusMark(t *testing.T) {
+	entries := []ListEntry{
+		{
+			Name: "a.txt",
+			Size: 10,
+			Replicas: []ListReplica{
+				{Status: "1"},
+			},
+		},
+	}
+
+	f, err := NewFormatter("long")
+	if err != nil {
+		t.Fatalf("NewFormatter: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf, entries); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "&") {
+		t.Errorf("expected text output to contain the status mark, got %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "\t1\t") {
+		t.Errorf("expected raw status %q not to leak into text output, got %q", "1", buf.String())
+	}
+}
+
+func TestGetStatusMark(t *testing.T) {
+	cases := map[string]string{
+		"0": "X",
+		"1": "&",
+		"9": "?",
+	}
+
+	for status, want := range cases {
+		if got := getStatusMark(status); got != want {
+			t.Errorf("getStatusMark(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
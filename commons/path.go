@@ -0,0 +1,40 @@
+package commons
+
+import (
+	"path"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// ResolveIRODSPath resolves a user-supplied iRODS path argument p against
+// cwd (for relative paths) and home (for "~" paths), returning both the
+// absolute form iRODS API calls require and the relative form callers
+// should keep using in user-facing messages. Paths that clean to
+// somewhere above the /zone root are rejected outright rather than
+// silently clamped to it.
+func ResolveIRODSPath(cwd string, home string, zone string, p string) (abs string, rel string, err error) {
+	switch {
+	case p == "~":
+		abs = path.Clean(home)
+	case strings.HasPrefix(p, "~/"):
+		abs = path.Clean(path.Join(home, p[2:]))
+	case path.IsAbs(p):
+		abs = path.Clean(p)
+	default:
+		abs = path.Clean(path.Join(cwd, p))
+	}
+
+	zoneRoot := path.Clean("/" + strings.Trim(zone, "/"))
+	if abs != zoneRoot && !strings.HasPrefix(abs, zoneRoot+"/") {
+		return "", "", xerrors.Errorf("path %q escapes zone %q", p, zone)
+	}
+
+	if path.IsAbs(p) {
+		rel = abs
+	} else {
+		rel = path.Clean(p)
+	}
+
+	return abs, rel, nil
+}
@@ -0,0 +1,88 @@
+package commons
+
+import "testing"
+
+func TestResolveIRODSPath(t *testing.T) {
+	const cwd = "/tempZone/home/user"
+	const home = "/tempZone/home/user"
+	const zone = "tempZone"
+
+	tests := []struct {
+		name    string
+		p       string
+		wantAbs string
+		wantRel string
+		wantErr bool
+	}{
+		{
+			name:    "relative path joins cwd",
+			p:       "foo/bar",
+			wantAbs: "/tempZone/home/user/foo/bar",
+			wantRel: "foo/bar",
+		},
+		{
+			name:    "dot is cwd itself",
+			p:       ".",
+			wantAbs: "/tempZone/home/user",
+			wantRel: ".",
+		},
+		{
+			name:    "dot-dot climbs within the zone",
+			p:       "../other",
+			wantAbs: "/tempZone/home/other",
+			wantRel: "../other",
+		},
+		{
+			name:    "absolute path is used as-is",
+			p:       "/tempZone/home/user/baz",
+			wantAbs: "/tempZone/home/user/baz",
+			wantRel: "/tempZone/home/user/baz",
+		},
+		{
+			name:    "tilde resolves to home",
+			p:       "~",
+			wantAbs: "/tempZone/home/user",
+			wantRel: "~",
+		},
+		{
+			name:    "tilde-prefixed path resolves under home",
+			p:       "~/foo",
+			wantAbs: "/tempZone/home/user/foo",
+			wantRel: "~/foo",
+		},
+		{
+			name:    "escape above the zone root is rejected",
+			p:       "../../../other-zone",
+			wantErr: true,
+		},
+		{
+			name:    "absolute escape above the zone root is rejected",
+			p:       "/otherZone/home/user",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			abs, rel, err := ResolveIRODSPath(cwd, home, zone, tt.p)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got abs=%q rel=%q", abs, rel)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if abs != tt.wantAbs {
+				t.Errorf("abs = %q, want %q", abs, tt.wantAbs)
+			}
+
+			if rel != tt.wantRel {
+				t.Errorf("rel = %q, want %q", rel, tt.wantRel)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package commons
+
+import (
+	"os"
+
+	"github.com/cheggaaa/pb/v3"
+	"golang.org/x/term"
+)
+
+// TransferProgress renders an aggregate progress bar for a batch upload
+// or download: bytes transferred, throughput, ETA and file counts.
+// Callers feed it real transfer progress via AddBytes from a
+// common.TransferTrackerCallback passed to UploadFileParallel, so the bar
+// reflects actual network bytes transferred rather than file-level or
+// local-disk-only estimates.
+type TransferProgress struct {
+	bar     *pb.ProgressBar
+	enabled bool
+}
+
+// NewTransferProgress creates a progress reporter for a transfer made up
+// of totalFiles files totalling totalBytes bytes. The bar is suppressed
+// when noProgress is set or stdout is not a terminal.
+func NewTransferProgress(totalFiles int, totalBytes int64, noProgress bool) *TransferProgress {
+	enabled := !noProgress && term.IsTerminal(int(os.Stdout.Fd()))
+
+	tp := &TransferProgress{enabled: enabled}
+	if !enabled {
+		return tp
+	}
+
+	tp.bar = pb.New64(totalBytes)
+	tp.bar.Set(pb.Bytes, true)
+	tp.bar.Set("files", totalFiles)
+	tp.bar.SetTemplateString(`{{counters . }} {{bar . }} {{speed . }} {{rtime . "ETA %s"}} ({{string . "files"}} files)`)
+	tp.bar.Start()
+
+	return tp
+}
+
+// AddBytes advances the aggregate bar by n bytes, typically the delta
+// between successive common.TransferTrackerCallback invocations for a
+// single file's upload.
+func (tp *TransferProgress) AddBytes(n int64) {
+	if !tp.enabled {
+		return
+	}
+
+	tp.bar.Add64(n)
+}
+
+// Finish stops the bar, leaving its final state printed.
+func (tp *TransferProgress) Finish() {
+	if !tp.enabled {
+		return
+	}
+
+	tp.bar.Finish()
+}
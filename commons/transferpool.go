@@ -0,0 +1,185 @@
+package commons
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+)
+
+// DefaultTransfers returns the default --transfers concurrency: min(8,
+// NumCPU).
+func DefaultTransfers() int {
+	n := runtime.NumCPU()
+	if n > 8 {
+		return 8
+	}
+
+	return n
+}
+
+// TransferTask is a single file to upload or download, discovered by a
+// directory walk and streamed into a TransferPool.
+type TransferTask struct {
+	SourcePath string
+	TargetPath string
+	Size       int64
+}
+
+// TransferFunc performs the actual upload or download for one task.
+type TransferFunc func(ctx context.Context, task TransferTask) error
+
+// TransferErrors aggregates the per-file failures collected when
+// IgnoreErrors is set, so the caller can report a summary instead of
+// stopping at the first failure.
+type TransferErrors struct {
+	Errors []error
+}
+
+func (e *TransferErrors) Error() string {
+	return fmt.Sprintf("%d of the requested transfers failed", len(e.Errors))
+}
+
+// TransferPool fans a stream of TransferTasks out across Transfers
+// concurrent workers, retrying transient failures with exponential
+// backoff, and keeps memory flat on huge trees since tasks are read
+// from a bounded channel fed by the directory walk rather than
+// collected up front.
+type TransferPool struct {
+	// Transfers is the number of files uploaded/downloaded concurrently.
+	// Zero means DefaultTransfers().
+	Transfers int
+	// Retries is the number of extra attempts made for a file transfer
+	// that fails with a transient error, as classified by
+	// isRetryableTransferError. Permanent failures (auth/config errors,
+	// a missing source file, a path that already exists, ...) are
+	// returned immediately without consuming a retry.
+	Retries int
+	// LowLevelRetries is reserved for per-request iRODS retries; plumbed
+	// through from --low-level-retries but not yet applied, since
+	// go-irodsclient's UploadFileParallel does not currently accept a
+	// low-level retry count.
+	LowLevelRetries int
+	// IgnoreErrors continues past failed transfers instead of cancelling
+	// the remaining ones, returning a *TransferErrors summary at the end.
+	IgnoreErrors bool
+	// DryRun reports what would be transferred without calling Transfer.
+	DryRun bool
+	// Transfer performs the actual upload/download for one task.
+	Transfer TransferFunc
+}
+
+// Run consumes tasks across Transfers workers until the channel is
+// closed, ctx is cancelled, or (when IgnoreErrors is unset) a transfer
+// fails. cancel is called on a fatal failure so that callers who derived
+// ctx from a shared, cancellable parent (e.g. to also stop a directory
+// walk feeding tasks) see the abort too, not just this pool's workers.
+// Run returns the first error, or, when IgnoreErrors is set, a
+// *TransferErrors summary of every failure (even if there was only one).
+func (p *TransferPool) Run(ctx context.Context, cancel context.CancelFunc, tasks <-chan TransferTask) error {
+	workers := p.Transfers
+	if workers <= 0 {
+		workers = DefaultTransfers()
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for task := range tasks {
+				if ctx.Err() != nil {
+					continue // keep draining so the walker never blocks on a full channel
+				}
+
+				if p.DryRun {
+					fmt.Printf("would transfer %s -> %s (%d bytes)\n", task.SourcePath, task.TargetPath, task.Size)
+					continue
+				}
+
+				if err := p.transferWithRetry(ctx, task); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s: %w", task.SourcePath, err))
+					mu.Unlock()
+
+					if !p.IgnoreErrors {
+						cancel()
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case !p.IgnoreErrors:
+		return errs[0]
+	default:
+		return &TransferErrors{Errors: errs}
+	}
+}
+
+func (p *TransferPool) transferWithRetry(ctx context.Context, task TransferTask) error {
+	attempts := p.Retries + 1
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		lastErr = p.Transfer(ctx, task)
+		if lastErr == nil {
+			return nil
+		}
+
+		if attempt == attempts-1 || !isRetryableTransferError(lastErr) {
+			break
+		}
+
+		backoff := time.Duration(1<<uint(attempt)) * 500 * time.Millisecond
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return lastErr
+}
+
+// isRetryableTransferError reports whether err is a transient iRODS
+// failure worth retrying with backoff, as opposed to a permanent one
+// that will fail again on every attempt: auth/connection-config
+// problems, a source path that's missing or already exists, an
+// unsupported API, or a non-empty collection. Unrecognized errors
+// (including plain connection errors, which iRODS itself distinguishes
+// from permanent failures) are treated as retryable, matching this
+// function's conservative default before it existed.
+func isRetryableTransferError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch {
+	case irodsclient_types.IsFileNotFoundError(err),
+		irodsclient_types.IsFileAlreadyExistError(err),
+		irodsclient_types.IsCollectionNotEmptyError(err),
+		irodsclient_types.IsAPINotSupportedError(err),
+		irodsclient_types.IsPermanantFailure(err):
+		return false
+	}
+
+	return true
+}
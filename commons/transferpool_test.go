@@ -0,0 +1,163 @@
+package commons
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+)
+
+func TestTransferPoolRunSucceeds(t *testing.T) {
+	var completed int32
+
+	pool := &TransferPool{
+		Transfers: 2,
+		Transfer: func(ctx context.Context, task TransferTask) error {
+			atomic.AddInt32(&completed, 1)
+			return nil
+		},
+	}
+
+	tasks := make(chan TransferTask, 3)
+	for i := 0; i < 3; i++ {
+		tasks <- TransferTask{SourcePath: "a"}
+	}
+	close(tasks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := pool.Run(ctx, cancel, tasks); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if completed != 3 {
+		t.Errorf("completed = %d, want 3", completed)
+	}
+}
+
+func TestTransferPoolRunRetriesThenSucceeds(t *testing.T) {
+	var attempts int32
+
+	pool := &TransferPool{
+		Transfers: 1,
+		Retries:   2,
+		Transfer: func(ctx context.Context, task TransferTask) error {
+			n := atomic.AddInt32(&attempts, 1)
+			if n < 3 {
+				return errors.New("transient")
+			}
+			return nil
+		},
+	}
+
+	tasks := make(chan TransferTask, 1)
+	tasks <- TransferTask{SourcePath: "a"}
+	close(tasks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := pool.Run(ctx, cancel, tasks); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3 (1 initial + 2 retries)", attempts)
+	}
+}
+
+func TestTransferPoolRunDoesNotRetryPermanentError(t *testing.T) {
+	var attempts int32
+	wantErr := irodsclient_types.NewFileNotFoundError("/tempZone/home/user/missing")
+
+	pool := &TransferPool{
+		Transfers: 1,
+		Retries:   2,
+		Transfer: func(ctx context.Context, task TransferTask) error {
+			atomic.AddInt32(&attempts, 1)
+			return wantErr
+		},
+	}
+
+	tasks := make(chan TransferTask, 1)
+	tasks <- TransferTask{SourcePath: "a"}
+	close(tasks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := pool.Run(ctx, cancel, tasks)
+	if err == nil || !errors.Is(err, wantErr) {
+		t.Fatalf("Run error = %v, want wrapping %v", err, wantErr)
+	}
+
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (a not-found error should not be retried)", attempts)
+	}
+}
+
+func TestTransferPoolRunStopsOnFirstErrorByDefault(t *testing.T) {
+	wantErr := errors.New("boom")
+
+	pool := &TransferPool{
+		Transfers: 1,
+		Transfer: func(ctx context.Context, task TransferTask) error {
+			return wantErr
+		},
+	}
+
+	tasks := make(chan TransferTask, 1)
+	tasks <- TransferTask{SourcePath: "a"}
+	close(tasks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := pool.Run(ctx, cancel, tasks)
+	if err == nil || !errors.Is(err, wantErr) {
+		t.Fatalf("Run error = %v, want wrapping %v", err, wantErr)
+	}
+
+	if ctx.Err() == nil {
+		t.Error("expected Run to cancel ctx on a fatal failure so a shared walker observes it")
+	}
+}
+
+func TestTransferPoolRunCollectsErrorsWhenIgnoreErrors(t *testing.T) {
+	pool := &TransferPool{
+		Transfers:    1,
+		IgnoreErrors: true,
+		Transfer: func(ctx context.Context, task TransferTask) error {
+			return errors.New("fail: " + task.SourcePath)
+		},
+	}
+
+	tasks := make(chan TransferTask, 2)
+	tasks <- TransferTask{SourcePath: "a"}
+	tasks <- TransferTask{SourcePath: "b"}
+	close(tasks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := pool.Run(ctx, cancel, tasks)
+	if err == nil {
+		t.Fatal("expected a *TransferErrors summary")
+	}
+
+	var transferErrs *TransferErrors
+	if !errors.As(err, &transferErrs) {
+		t.Fatalf("error = %v, want *TransferErrors", err)
+	}
+
+	if len(transferErrs.Errors) != 2 {
+		t.Errorf("got %d errors, want 2", len(transferErrs.Errors))
+	}
+
+	if ctx.Err() != nil {
+		t.Error("IgnoreErrors should not cancel ctx")
+	}
+}